@@ -0,0 +1,22 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// newEnvCmd returns the "env" command tree, wiring in each of its subcommands.
+func newEnvCmd(envcmd *envCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "env",
+		Short: "Manage environments",
+	}
+
+	cmd.AddCommand(newEnvOpenCmd(envcmd))
+	cmd.AddCommand(newEnvRunCmd(envcmd))
+	cmd.AddCommand(newEnvCacheCmd(envcmd))
+	cmd.AddCommand(newEnvVarsCmd(envcmd))
+
+	return cmd
+}
@@ -0,0 +1,38 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"github.com/pulumi/esc/internal/cache"
+	"github.com/spf13/cobra"
+)
+
+func newEnvCacheCmd(envcmd *envCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "cache",
+		Args:  cobra.NoArgs,
+		Short: "Manage the local cache of opened environments.",
+	}
+
+	cmd.AddCommand(newEnvCacheClearCmd(envcmd))
+
+	return cmd
+}
+
+func newEnvCacheClearCmd(envcmd *envCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "clear",
+		Args:  cobra.NoArgs,
+		Short: "Remove all cached environment opens.",
+		Long: "Remove all cached environment opens\n" +
+			"\n" +
+			"This command removes every entry written by `esc env open`'s local cache,\n" +
+			"forcing the next `esc env open` of any environment to re-open it from the server.\n",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return cache.Clear()
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,94 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/pulumi/esc/cmd/esc/cli/client"
+	"golang.org/x/exp/maps"
+)
+
+// expandMode controls how `${VAR}`/`$VAR` references in environmentVariables values are
+// resolved before they are emitted.
+type expandMode string
+
+const (
+	// expandNone leaves values untouched.
+	expandNone expandMode = "none"
+	// expandSelf resolves references against other environmentVariables values only.
+	expandSelf expandMode = "self"
+	// expandEnv resolves references against other environmentVariables values, falling
+	// back to the calling process's environment for anything left unresolved.
+	expandEnv expandMode = "env"
+)
+
+func parseExpandMode(s string) (expandMode, error) {
+	switch expandMode(s) {
+	case expandNone, expandSelf, expandEnv:
+		return expandMode(s), nil
+	default:
+		return "", fmt.Errorf("unknown --expand mode %q: must be 'env', 'self', or 'none'", s)
+	}
+}
+
+// expandEnvironmentVariables resolves `${VAR}`/`$VAR` references in values using
+// os.Expand, against the other entries of values (and, in expandEnv mode, the calling
+// process's environment). References are resolved in topological order so that, e.g.,
+// FOO=bar and BAZ=${FOO}/qux produce BAZ=bar/qux. Cycles are reported as diagnostics and
+// resolve to an empty string.
+func expandEnvironmentVariables(
+	values map[string]string,
+	mode expandMode,
+) (map[string]string, []client.EnvironmentDiagnostic) {
+	if mode == expandNone {
+		return values, nil
+	}
+
+	const (
+		stateUnvisited = iota
+		stateVisiting
+		stateDone
+	)
+
+	resolved := make(map[string]string, len(values))
+	state := make(map[string]int, len(values))
+	var diags []client.EnvironmentDiagnostic
+
+	var resolve func(name string) string
+	lookup := func(ref string) string {
+		if _, ok := values[ref]; ok {
+			return resolve(ref)
+		}
+		if mode == expandEnv {
+			return os.Getenv(ref)
+		}
+		return ""
+	}
+	resolve = func(name string) string {
+		switch state[name] {
+		case stateDone:
+			return resolved[name]
+		case stateVisiting:
+			diags = append(diags, client.EnvironmentDiagnostic{
+				Summary: fmt.Sprintf("cycle detected while expanding environment variable %q", name),
+			})
+			return ""
+		}
+
+		state[name] = stateVisiting
+		expanded := os.Expand(values[name], lookup)
+		state[name] = stateDone
+		resolved[name] = expanded
+		return expanded
+	}
+
+	names := maps.Keys(values)
+	sort.Strings(names)
+	for _, name := range names {
+		resolve(name)
+	}
+	return resolved, diags
+}
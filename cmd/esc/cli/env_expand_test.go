@@ -0,0 +1,77 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestExpandEnvironmentVariables(t *testing.T) {
+	t.Run("none leaves values untouched", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"FOO": "bar", "BAZ": "${FOO}/qux"}
+		resolved, diags := expandEnvironmentVariables(values, expandNone)
+		assert.Empty(t, diags)
+		assert.Equal(t, values, resolved)
+	})
+
+	t.Run("self resolves references topologically", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"FOO": "bar", "BAZ": "${FOO}/qux"}
+		resolved, diags := expandEnvironmentVariables(values, expandSelf)
+		assert.Empty(t, diags)
+		assert.Equal(t, "bar", resolved["FOO"])
+		assert.Equal(t, "bar/qux", resolved["BAZ"])
+	})
+
+	t.Run("self leaves unresolved references empty", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"FOO": "${NOT_DEFINED}"}
+		resolved, diags := expandEnvironmentVariables(values, expandSelf)
+		assert.Empty(t, diags)
+		assert.Equal(t, "", resolved["FOO"])
+	})
+
+	t.Run("env falls back to the calling process's environment", func(t *testing.T) {
+		t.Setenv("ESC_EXPAND_TEST_PARENT", "parent-value")
+		values := map[string]string{"FOO": "${ESC_EXPAND_TEST_PARENT}"}
+		resolved, diags := expandEnvironmentVariables(values, expandEnv)
+		assert.Empty(t, diags)
+		assert.Equal(t, "parent-value", resolved["FOO"])
+	})
+
+	t.Run("direct cycle is detected and reported", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"A": "${B}", "B": "${A}"}
+		resolved, diags := expandEnvironmentVariables(values, expandSelf)
+		require.Len(t, diags, 1, "the node where the cycle is detected is reported once")
+		assert.Equal(t, "", resolved["A"])
+		assert.Equal(t, "", resolved["B"])
+	})
+
+	t.Run("indirect cycle is detected and reported", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"A": "${B}", "B": "${C}", "C": "${A}"}
+		_, diags := expandEnvironmentVariables(values, expandSelf)
+		assert.NotEmpty(t, diags)
+	})
+
+	t.Run("self-reference is detected as a cycle", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"A": "${A}"}
+		resolved, diags := expandEnvironmentVariables(values, expandSelf)
+		require.Len(t, diags, 1)
+		assert.Equal(t, "", resolved["A"])
+	})
+
+	t.Run("a cycle elsewhere does not affect unrelated vars", func(t *testing.T) {
+		t.Parallel()
+		values := map[string]string{"A": "${B}", "B": "${A}", "FOO": "bar"}
+		resolved, diags := expandEnvironmentVariables(values, expandSelf)
+		assert.NotEmpty(t, diags)
+		assert.Equal(t, "bar", resolved["FOO"])
+	})
+}
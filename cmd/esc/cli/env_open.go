@@ -4,22 +4,32 @@ package cli
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"sort"
 	"time"
 
+	"github.com/BurntSushi/toml"
 	"github.com/pulumi/esc"
 	"github.com/pulumi/esc/cmd/esc/cli/client"
+	"github.com/pulumi/esc/internal/cache"
 	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
 	"github.com/spf13/cobra"
 	"golang.org/x/exp/maps"
+	"gopkg.in/yaml.v3"
 )
 
 func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 	var duration time.Duration
 	var format string
+	var properties []string
+	var expand string
+	var noCache bool
+	var watch bool
+	var pollInterval time.Duration
 
 	cmd := &cobra.Command{
 		Use:   "open [<org-name>/]<environment-name> [property path]",
@@ -28,7 +38,11 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 		Long: "Open the environment with the given name and return the result\n" +
 			"\n" +
 			"This command opens the environment with the given name. The result is written to\n" +
-			"stdout as JSON. If a property path is specified, only retrieves that property.\n",
+			"stdout as JSON. If a property path is specified, only retrieves that property. The\n" +
+			"--property flag may be repeated to project multiple property paths into the output.\n" +
+			"\n" +
+			"If --watch is set, the environment is re-opened every --poll-interval and any\n" +
+			"changes are re-rendered to stdout.\n",
 		SilenceUsage: true,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			ctx := context.Background()
@@ -37,33 +51,42 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 				return err
 			}
 
+			expandMode, err := parseExpandMode(expand)
+			if err != nil {
+				return err
+			}
+
 			orgName, envName, args, err := envcmd.getEnvName(args)
 			if err != nil {
 				return err
 			}
-			_ = args
 
-			var path resource.PropertyPath
+			propertyArgs := properties
 			if len(args) == 1 {
-				p, err := resource.ParsePropertyPath(args[0])
+				propertyArgs = append([]string{args[0]}, propertyArgs...)
+			}
+
+			paths := make([]resource.PropertyPath, len(propertyArgs))
+			for i, arg := range propertyArgs {
+				p, err := resource.ParsePropertyPath(arg)
 				if err != nil {
-					return fmt.Errorf("invalid property path %v: %w", args[0], err)
+					return fmt.Errorf("invalid property path %v: %w", arg, err)
 				}
-				path = p
+				paths[i] = p
 			}
 
 			switch format {
-			case "detailed", "json", "string":
+			case "detailed", "json", "string", "yaml", "toml":
 				// OK
-			case "dotenv", "shell":
-				if len(path) != 0 {
+			case "dotenv", "shell", "tfvars", "k8s-secret", "docker-env":
+				if len(paths) != 0 {
 					return fmt.Errorf("output format '%s' may not be used with a property path", format)
 				}
 			default:
 				return fmt.Errorf("unknown output format %q", format)
 			}
 
-			env, diags, err := envcmd.openEnvironment(ctx, orgName, envName, duration)
+			env, diags, err := envcmd.openEnvironment(ctx, orgName, envName, duration, noCache)
 			if err != nil {
 				return err
 			}
@@ -71,16 +94,46 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 				return envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, diags)
 			}
 
-			return renderValue(envcmd.esc.stdout, env, path, format)
+			expandDiags, err := renderValue(envcmd.esc.stdout, env, paths, format, expandMode)
+			if err != nil {
+				return err
+			}
+			if len(expandDiags) != 0 {
+				if err := envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, expandDiags); err != nil {
+					return err
+				}
+			}
+
+			if !watch {
+				return nil
+			}
+			return watchEnvironment(ctx, envcmd, orgName, envName, duration, pollInterval, format, expandMode, paths, env)
 		},
 	}
 
 	cmd.Flags().DurationVarP(
-		&duration, "lifetime", "l", 2*time.Hour,
+		&duration, "lifetime", "l", Lifetime.Value(),
 		"the lifetime of the opened environment in the form HhMm (e.g. 2h, 1h30m, 15m)")
 	cmd.Flags().StringVarP(
-		&format, "format", "f", "json",
-		"the output format to use. May be 'dotenv', 'json', 'detailed', or 'shell'")
+		&format, "format", "f", Format.Value(),
+		"the output format to use. May be 'dotenv', 'json', 'detailed', 'shell', 'yaml', 'toml', "+
+			"'tfvars', 'k8s-secret', or 'docker-env'")
+	cmd.Flags().StringArrayVarP(
+		&properties, "property", "p", nil,
+		"a property path to project into the output, may be repeated")
+	cmd.Flags().StringVar(
+		&expand, "expand", string(expandSelf),
+		"how to resolve ${VAR}/$VAR references in environmentVariables values. "+
+			"May be 'env', 'self', or 'none'")
+	cmd.Flags().BoolVar(
+		&noCache, "no-cache", false,
+		"bypass the local environment cache and always re-open the environment")
+	cmd.Flags().BoolVar(
+		&watch, "watch", false,
+		"after the initial open, watch the environment definition for changes and re-render on each change")
+	cmd.Flags().DurationVar(
+		&pollInterval, "poll-interval", 10*time.Second,
+		"how often to poll for changes when --watch is set")
 
 	return cmd
 }
@@ -88,83 +141,240 @@ func newEnvOpenCmd(envcmd *envCommand) *cobra.Command {
 func renderValue(
 	out io.Writer,
 	env *esc.Environment,
-	path resource.PropertyPath,
+	paths []resource.PropertyPath,
 	format string,
-) error {
+	expand expandMode,
+) ([]client.EnvironmentDiagnostic, error) {
 	if env == nil {
-		return nil
+		return nil, nil
 	}
 
-	val := esc.NewValue(env.Properties)
-	if len(path) != 0 {
-		if vv, ok := getEnvValue(val, path); ok {
-			val = *vv
-		} else {
-			val = esc.Value{}
-		}
-	}
+	val := projectValue(esc.NewValue(env.Properties), paths)
 
 	switch format {
 	case "json":
 		body := val.ToJSON(false)
 		enc := json.NewEncoder(out)
 		enc.SetIndent("", "  ")
-		return enc.Encode(body)
+		return nil, enc.Encode(body)
 	case "detailed":
 		enc := json.NewEncoder(out)
 		enc.SetIndent("", "  ")
-		return enc.Encode(val)
+		return nil, enc.Encode(val)
 	case "dotenv":
-		for _, kvp := range getEnvironmentVariables(env) {
+		environ, diags := getEnvironmentVariables(env, expand)
+		for _, kvp := range environ {
 			fmt.Fprintln(out, kvp)
 		}
-		return nil
+		return diags, nil
 	case "shell":
-		for _, kvp := range getEnvironmentVariables(env) {
+		environ, diags := getEnvironmentVariables(env, expand)
+		for _, kvp := range environ {
 			fmt.Fprintf(out, "export %v\n", kvp)
 		}
-		return nil
+		return diags, nil
+	case "docker-env":
+		values, diags := getExpandedEnvironmentVariableValues(env, expand)
+		keys := maps.Keys(values)
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(out, "%v=%v\n", k, values[k])
+		}
+		return diags, nil
+	case "yaml":
+		body := val.ToJSON(false)
+		bytes, err := yaml.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("marshaling yaml: %w", err)
+		}
+		_, err = out.Write(bytes)
+		return nil, err
+	case "toml":
+		body := val.ToJSON(false)
+		enc := toml.NewEncoder(out)
+		return nil, enc.Encode(body)
+	case "tfvars":
+		values, diags := getExpandedEnvironmentVariableValues(env, expand)
+		return diags, renderTFVars(out, values)
+	case "k8s-secret":
+		diags, err := renderK8sSecret(out, env, expand)
+		return diags, err
 	case "string":
 		fmt.Fprintf(out, "%v\n", val.ToString(false))
-		return nil
+		return nil, nil
 	default:
 		// NOTE: we shouldn't get here. This was checked at the beginning of the function.
-		return fmt.Errorf("unknown output format %q", format)
+		return nil, fmt.Errorf("unknown output format %q", format)
 	}
 
 }
 
-func getEnvironmentVariables(env *esc.Environment) []string {
-	vars, ok := env.Properties["environmentVariables"].Value.(map[string]esc.Value)
-	if !ok {
-		return nil
+// projectValue projects zero or more property paths out of val. With no paths, val is
+// returned unchanged. With one path, the value at that path is returned. With more than
+// one, the results are collected into a map keyed by the string form of each path.
+func projectValue(val esc.Value, paths []resource.PropertyPath) esc.Value {
+	switch len(paths) {
+	case 0:
+		return val
+	case 1:
+		if vv, ok := getEnvValue(val, paths[0]); ok {
+			return *vv
+		}
+		return esc.Value{}
+	default:
+		projected := map[string]esc.Value{}
+		for _, path := range paths {
+			if vv, ok := getEnvValue(val, path); ok {
+				projected[path.String()] = *vv
+			} else {
+				projected[path.String()] = esc.Value{}
+			}
+		}
+		return esc.NewValue(projected)
 	}
-	keys := maps.Keys(vars)
+}
+
+// renderTFVars renders the environment's `environmentVariables` as HCL variable
+// assignments suitable for a Terraform -var-file.
+func renderTFVars(out io.Writer, values map[string]string) error {
+	keys := maps.Keys(values)
+	sort.Strings(keys)
+	for _, k := range keys {
+		body, err := json.Marshal(values[k])
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "%v = %v\n", k, string(body))
+	}
+	return nil
+}
+
+// renderK8sSecret renders the environment's `environmentVariables` as a Kubernetes
+// v1.Secret manifest, base64-encoding each value.
+func renderK8sSecret(
+	out io.Writer,
+	env *esc.Environment,
+	expand expandMode,
+) ([]client.EnvironmentDiagnostic, error) {
+	values, diags := getExpandedEnvironmentVariableValues(env, expand)
+
+	data := map[string]string{}
+	for k, v := range values {
+		data[k] = base64.StdEncoding.EncodeToString([]byte(v))
+	}
+
+	secret := map[string]any{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata": map[string]any{
+			"name": "esc-env",
+		},
+		"type": "Opaque",
+		"data": data,
+	}
+
+	bytes, err := yaml.Marshal(secret)
+	if err != nil {
+		return diags, fmt.Errorf("marshaling k8s secret: %w", err)
+	}
+	_, err = out.Write(bytes)
+	return diags, err
+}
+
+// getEnvironmentVariables renders the environment's `environmentVariables` property as
+// `NAME="value"` pairs, sorted by name, after resolving references per expand.
+func getEnvironmentVariables(env *esc.Environment, expand expandMode) ([]string, []client.EnvironmentDiagnostic) {
+	values, diags := getExpandedEnvironmentVariableValues(env, expand)
+	keys := maps.Keys(values)
 	sort.Strings(keys)
 
 	var environ []string
 	for _, k := range keys {
-		v := vars[k]
+		environ = append(environ, fmt.Sprintf("%v=%q", k, values[k]))
+	}
+	return environ, diags
+}
+
+// getEnvironmentVariableValues returns the string-valued entries of the environment's
+// `environmentVariables` property, keyed by name.
+func getEnvironmentVariableValues(env *esc.Environment) map[string]string {
+	vars, ok := env.Properties["environmentVariables"].Value.(map[string]esc.Value)
+	if !ok {
+		return nil
+	}
+
+	values := make(map[string]string, len(vars))
+	for k, v := range vars {
 		if strValue, ok := v.Value.(string); ok {
-			environ = append(environ, fmt.Sprintf("%v=%q", k, strValue))
+			values[k] = strValue
 		}
 	}
-	return environ
+	return values
+}
+
+// getExpandedEnvironmentVariableValues returns getEnvironmentVariableValues with
+// ${VAR}/$VAR references resolved per expand.
+func getExpandedEnvironmentVariableValues(
+	env *esc.Environment,
+	expand expandMode,
+) (map[string]string, []client.EnvironmentDiagnostic) {
+	return expandEnvironmentVariables(getEnvironmentVariableValues(env), expand)
+}
+
+// environmentDiagnosticsError carries diagnostics returned by opening an environment
+// through cache.Open's refresh callback, which can only return a single error value.
+type environmentDiagnosticsError struct {
+	diags []client.EnvironmentDiagnostic
+}
+
+func (e *environmentDiagnosticsError) Error() string {
+	return "environment has diagnostics"
 }
 
+// openEnvironment opens the named environment, consulting the local cache first unless
+// noCache is set. A cache hit is only used while it has not yet reached the expiration
+// computed from the requested lifetime when it was opened. Concurrent callers racing on
+// a cold or expired cache entry for the same org/env collapse onto a single API
+// round-trip; see cache.Open.
 func (env *envCommand) openEnvironment(
 	ctx context.Context,
 	orgName string,
 	envName string,
 	duration time.Duration,
+	noCache bool,
 ) (*esc.Environment, []client.EnvironmentDiagnostic, error) {
-	envID, diags, err := env.esc.client.OpenEnvironment(ctx, orgName, envName, duration)
+	refresh := func() (*cache.Entry, error) {
+		envID, diags, err := env.esc.client.OpenEnvironment(ctx, orgName, envName, duration)
+		if err != nil {
+			return nil, err
+		}
+		if len(diags) != 0 {
+			return nil, &environmentDiagnosticsError{diags: diags}
+		}
+		open, err := env.esc.client.GetOpenEnvironment(ctx, orgName, envName, envID)
+		if err != nil {
+			return nil, err
+		}
+		return &cache.Entry{EnvID: envID, Env: open, Expires: time.Now().Add(duration)}, nil
+	}
+
+	var entry *cache.Entry
+	var err error
+	if noCache {
+		entry, err = refresh()
+	} else {
+		entry, err = cache.Open(orgName, envName, func(e *cache.Entry) bool {
+			return time.Now().Before(e.Expires)
+		}, refresh)
+	}
+
 	if err != nil {
+		var diagsErr *environmentDiagnosticsError
+		if errors.As(err, &diagsErr) {
+			return nil, diagsErr.diags, nil
+		}
 		return nil, nil, err
 	}
-	if len(diags) != 0 {
-		return nil, diags, err
-	}
-	open, err := env.esc.client.GetOpenEnvironment(ctx, orgName, envName, envID)
-	return open, nil, err
-}
\ No newline at end of file
+
+	return entry.Env, nil, nil
+}
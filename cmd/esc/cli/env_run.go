@@ -0,0 +1,209 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/pulumi/esc"
+	"github.com/pulumi/esc/cmd/esc/cli/client"
+	"github.com/spf13/cobra"
+)
+
+func newEnvRunCmd(envcmd *envCommand) *cobra.Command {
+	var duration time.Duration
+	var vars []string
+	var varFiles []string
+	var expand string
+	var noCache bool
+
+	cmd := &cobra.Command{
+		Use:   "run [<org-name>/]<environment-name> -- <command> [args...]",
+		Args:  cobra.MinimumNArgs(2),
+		Short: "Open the environment with the given name and run a command inside it.",
+		Long: "Open the environment with the given name and run a command inside it\n" +
+			"\n" +
+			"This command opens the environment with the given name, injects its\n" +
+			"`environmentVariables` into the environment of the given command, and\n" +
+			"execs the command. Variables from the opened environment are merged over\n" +
+			"the current process's environment, and may themselves be overridden with\n" +
+			"one or more --var and --var-file flags.\n",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ctx := context.Background()
+
+			if err := envcmd.esc.getCachedClient(ctx); err != nil {
+				return err
+			}
+
+			sep := cmd.ArgsLenAtDash()
+			if sep < 0 {
+				return fmt.Errorf("expected a command to run after '--'")
+			}
+
+			orgName, envName, rest, err := envcmd.getEnvName(args[:sep])
+			if err != nil {
+				return err
+			}
+			if len(rest) != 0 {
+				return fmt.Errorf("unexpected argument %q", rest[0])
+			}
+
+			command := args[sep:]
+			if len(command) == 0 {
+				return fmt.Errorf("expected a command to run after '--'")
+			}
+
+			expandMode, err := parseExpandMode(expand)
+			if err != nil {
+				return err
+			}
+
+			env, diags, err := envcmd.openEnvironment(ctx, orgName, envName, duration, noCache)
+			if err != nil {
+				return err
+			}
+			if len(diags) != 0 {
+				return envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, diags)
+			}
+
+			overrides, err := parseVarOverrides(vars, varFiles)
+			if err != nil {
+				return err
+			}
+
+			environ, expandDiags, err := buildChildEnviron(env, expandMode, overrides)
+			if err != nil {
+				return err
+			}
+			if len(expandDiags) != 0 {
+				return envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, expandDiags)
+			}
+
+			child := exec.CommandContext(ctx, command[0], command[1:]...)
+			child.Env = environ
+			child.Stdin = os.Stdin
+			child.Stdout = os.Stdout
+			child.Stderr = os.Stderr
+
+			if err := child.Run(); err != nil {
+				if exitErr, ok := err.(*exec.ExitError); ok {
+					os.Exit(exitErr.ExitCode())
+				}
+				return fmt.Errorf("running %v: %w", command[0], err)
+			}
+			return nil
+		},
+	}
+
+	cmd.Flags().DurationVarP(
+		&duration, "lifetime", "l", Lifetime.Value(),
+		"the lifetime of the opened environment in the form HhMm (e.g. 2h, 1h30m, 15m)")
+	cmd.Flags().StringArrayVar(
+		&vars, "var", nil,
+		"an additional NAME=VALUE pair to set in the child process's environment, may be repeated")
+	cmd.Flags().StringArrayVar(
+		&varFiles, "var-file", nil,
+		"a dotenv-formatted file of additional variables to set in the child process's environment, may be repeated")
+	cmd.Flags().StringVar(
+		&expand, "expand", string(expandSelf),
+		"how to resolve ${VAR}/$VAR references in environmentVariables values. "+
+			"May be 'env', 'self', or 'none'")
+	cmd.Flags().BoolVar(
+		&noCache, "no-cache", false,
+		"bypass the local environment cache and always re-open the environment")
+
+	return cmd
+}
+
+// parseVarOverrides parses --var and --var-file flags into a single set of NAME=VALUE
+// overrides. Every --var-file is applied first, in the order given, then every --var, in
+// the order given; later entries take precedence over earlier ones, so a --var always
+// overrides a --var-file entry of the same name.
+// --var-file contents are parsed with godotenv, so quoting, escaping, and `export NAME=`
+// lines behave the way they would in a real dotenv file.
+func parseVarOverrides(vars []string, varFiles []string) (map[string]string, error) {
+	overrides := map[string]string{}
+
+	for _, file := range varFiles {
+		parsed, err := godotenv.Read(file)
+		if err != nil {
+			return nil, fmt.Errorf("reading %v: %w", file, err)
+		}
+		for name, value := range parsed {
+			if !isEnvVarName(name) {
+				return nil, fmt.Errorf("%v: invalid variable name %q", file, name)
+			}
+			overrides[name] = value
+		}
+	}
+
+	for _, kvp := range vars {
+		name, value, ok := strings.Cut(kvp, "=")
+		if !ok {
+			return nil, fmt.Errorf("expected NAME=VALUE, got %q", kvp)
+		}
+		if !isEnvVarName(name) {
+			return nil, fmt.Errorf("invalid variable name %q", name)
+		}
+		overrides[name] = value
+	}
+
+	return overrides, nil
+}
+
+// isEnvVarName reports whether name is a valid POSIX environment variable name:
+// a non-empty sequence of letters, digits and underscores that does not start with a digit.
+func isEnvVarName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z'):
+			// OK
+		case '0' <= r && r <= '9':
+			if i == 0 {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// buildChildEnviron merges the environment variables of env over the current process's
+// environment, then applies overrides on top.
+func buildChildEnviron(
+	env *esc.Environment,
+	expand expandMode,
+	overrides map[string]string,
+) ([]string, []client.EnvironmentDiagnostic, error) {
+	merged := map[string]string{}
+	for _, kv := range os.Environ() {
+		name, value, _ := strings.Cut(kv, "=")
+		merged[name] = value
+	}
+
+	values, diags := getExpandedEnvironmentVariableValues(env, expand)
+	for name, value := range values {
+		merged[name] = value
+	}
+
+	for name, value := range overrides {
+		merged[name] = value
+	}
+
+	environ := make([]string, 0, len(merged))
+	for name, value := range merged {
+		environ = append(environ, name+"="+value)
+	}
+	return environ, diags, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pulumi/esc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsEnvVarName(t *testing.T) {
+	t.Parallel()
+
+	cases := map[string]bool{
+		"FOO":     true,
+		"foo_bar": true,
+		"_FOO":    true,
+		"FOO1":    true,
+		"1FOO":    false,
+		"FOO-BAR": false,
+		"FOO BAR": false,
+		"":        false,
+		"FOO.BAR": false,
+	}
+	for name, want := range cases {
+		assert.Equal(t, want, isEnvVarName(name), "name %q", name)
+	}
+}
+
+func TestParseVarOverrides(t *testing.T) {
+	t.Parallel()
+
+	t.Run("vars only, later wins", func(t *testing.T) {
+		t.Parallel()
+		overrides, err := parseVarOverrides([]string{"FOO=bar", "FOO=baz"}, nil)
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"FOO": "baz"}, overrides)
+	})
+
+	t.Run("invalid var", func(t *testing.T) {
+		t.Parallel()
+		_, err := parseVarOverrides([]string{"not-a-valid-name=1"}, nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("var file with quoting", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "vars.env")
+		require.NoError(t, os.WriteFile(path, []byte(`FOO="bar baz"
+export BAR=qux
+# a comment
+`), 0o600))
+
+		overrides, err := parseVarOverrides(nil, []string{path})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"FOO": "bar baz", "BAR": "qux"}, overrides)
+	})
+
+	t.Run("var file then vars, vars win", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		path := filepath.Join(dir, "vars.env")
+		require.NoError(t, os.WriteFile(path, []byte("FOO=from-file\n"), 0o600))
+
+		overrides, err := parseVarOverrides([]string{"FOO=from-flag"}, []string{path})
+		require.NoError(t, err)
+		assert.Equal(t, "from-flag", overrides["FOO"])
+	})
+}
+
+func TestBuildChildEnviron(t *testing.T) {
+	t.Setenv("ESC_RUN_TEST_PARENT", "parent-value")
+
+	env := &esc.Environment{
+		Properties: map[string]esc.Value{
+			"environmentVariables": esc.NewValue(map[string]esc.Value{
+				"ESC_RUN_TEST_PARENT": esc.NewValue("env-value"),
+				"ESC_RUN_TEST_ONLY":   esc.NewValue("only-value"),
+			}),
+		},
+	}
+
+	environ, diags, err := buildChildEnviron(env, expandNone, map[string]string{
+		"ESC_RUN_TEST_ONLY": "override-value",
+	})
+	require.NoError(t, err)
+	assert.Empty(t, diags)
+
+	values := map[string]string{}
+	for _, kv := range environ {
+		name, value, ok := strings.Cut(kv, "=")
+		require.True(t, ok)
+		values[name] = value
+	}
+
+	assert.Equal(t, "env-value", values["ESC_RUN_TEST_PARENT"])
+	assert.Equal(t, "override-value", values["ESC_RUN_TEST_ONLY"])
+}
@@ -0,0 +1,34 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"fmt"
+	"text/tabwriter"
+
+	"github.com/pulumi/esc/internal/env"
+	"github.com/spf13/cobra"
+)
+
+func newEnvVarsCmd(envcmd *envCommand) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vars",
+		Args:  cobra.NoArgs,
+		Short: "List the PULUMI_ESC_* environment variables understood by the CLI.",
+		Long: "List the PULUMI_ESC_* environment variables understood by the CLI\n" +
+			"\n" +
+			"This command prints the name, description, and current value of every\n" +
+			"PULUMI_ESC_* environment variable the CLI consults, similar to `go env`.\n",
+		SilenceUsage: true,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			w := tabwriter.NewWriter(envcmd.esc.stdout, 0, 4, 2, ' ', 0)
+			fmt.Fprintln(w, "NAME\tVALUE\tDESCRIPTION")
+			for _, v := range env.All() {
+				fmt.Fprintf(w, "%v\t%v\t%v\n", v.Name(), v, v.Description())
+			}
+			return w.Flush()
+		},
+	}
+
+	return cmd
+}
@@ -0,0 +1,135 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pulumi/esc"
+	"github.com/pulumi/pulumi/sdk/v3/go/common/resource"
+)
+
+// watchEnvironment polls the named environment every pollInterval, re-rendering to
+// envcmd.esc.stdout whenever its definition changes. In the dotenv/shell formats, only
+// the changes themselves are emitted: `unset` lines for keys that disappeared, and new
+// `export`/assignment lines for keys that appeared or changed. In every other format,
+// the full output is re-rendered, but only when it differs from the last render. It
+// runs until ctx is canceled or a fatal error occurs opening the environment.
+//
+// This is the poll-interval fallback only. A client.WatchEnvironment primitive that a
+// future SSE/WebSocket subscription could build on, under cmd/esc/cli/client, is not
+// part of this tree; this implementation polls via the existing openEnvironment/--no-cache
+// path instead.
+func watchEnvironment(
+	ctx context.Context,
+	envcmd *envCommand,
+	orgName, envName string,
+	duration, pollInterval time.Duration,
+	format string,
+	expand expandMode,
+	paths []resource.PropertyPath,
+	initial *esc.Environment,
+) error {
+	prevValues, _ := getExpandedEnvironmentVariableValues(initial, expand)
+	prevRendered, err := renderValueBytes(initial, paths, format, expand)
+	if err != nil {
+		return err
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+
+		env, diags, err := envcmd.openEnvironment(ctx, orgName, envName, duration, true /*noCache*/)
+		if err != nil {
+			return err
+		}
+		if len(diags) != 0 {
+			if err := envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, diags); err != nil {
+				return err
+			}
+			continue
+		}
+
+		switch format {
+		case "dotenv", "shell":
+			values, diags := getExpandedEnvironmentVariableValues(env, expand)
+			if len(diags) != 0 {
+				if err := envcmd.writePropertyEnvironmentDiagnostics(envcmd.esc.stderr, diags); err != nil {
+					return err
+				}
+			}
+			writeEnvironmentDiff(envcmd.esc.stdout, prevValues, values, format)
+			prevValues = values
+		default:
+			rendered, err := renderValueBytes(env, paths, format, expand)
+			if err != nil {
+				return err
+			}
+			if !bytes.Equal(rendered, prevRendered) {
+				if _, err := envcmd.esc.stdout.Write(rendered); err != nil {
+					return err
+				}
+				prevRendered = rendered
+			}
+		}
+	}
+}
+
+// renderValueBytes renders env via renderValue into a buffer instead of writing
+// directly to an output stream, so callers can compare successive renders before
+// deciding whether to emit anything.
+func renderValueBytes(
+	env *esc.Environment,
+	paths []resource.PropertyPath,
+	format string,
+	expand expandMode,
+) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := renderValue(&buf, env, paths, format, expand); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeEnvironmentDiff writes the changes between prev and next as dotenv or shell
+// lines: `unset NAME` for keys removed from next, then `export NAME="value"` (shell) or
+// `NAME="value"` (dotenv) for keys that are new or whose value changed.
+func writeEnvironmentDiff(out io.Writer, prev, next map[string]string, format string) {
+	var removed, changed []string
+	for k := range prev {
+		if _, ok := next[k]; !ok {
+			removed = append(removed, k)
+		}
+	}
+	for k, v := range next {
+		if old, ok := prev[k]; !ok || old != v {
+			changed = append(changed, k)
+		}
+	}
+
+	sort.Strings(removed)
+	for _, k := range removed {
+		fmt.Fprintf(out, "unset %v\n", k)
+	}
+
+	sort.Strings(changed)
+	for _, k := range changed {
+		if format == "shell" {
+			fmt.Fprintf(out, "export %v=%q\n", k, next[k])
+		} else {
+			fmt.Fprintf(out, "%v=%q\n", k, next[k])
+		}
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWriteEnvironmentDiff(t *testing.T) {
+	t.Parallel()
+
+	t.Run("dotenv emits unset then changed, sorted", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		prev := map[string]string{"REMOVED": "old", "UNCHANGED": "same", "CHANGED": "old-value"}
+		next := map[string]string{"UNCHANGED": "same", "CHANGED": "new-value", "ADDED": "new"}
+
+		writeEnvironmentDiff(&buf, prev, next, "dotenv")
+
+		assert.Equal(t, "unset REMOVED\nADDED=\"new\"\nCHANGED=\"new-value\"\n", buf.String())
+	})
+
+	t.Run("shell exports changed values", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		prev := map[string]string{"FOO": "bar"}
+		next := map[string]string{"FOO": "baz"}
+
+		writeEnvironmentDiff(&buf, prev, next, "shell")
+
+		assert.Equal(t, "export FOO=\"baz\"\n", buf.String())
+	})
+
+	t.Run("no changes emits nothing", func(t *testing.T) {
+		t.Parallel()
+		var buf bytes.Buffer
+		values := map[string]string{"FOO": "bar"}
+
+		writeEnvironmentDiff(&buf, values, values, "dotenv")
+
+		assert.Empty(t, buf.String())
+	})
+}
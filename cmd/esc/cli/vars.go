@@ -0,0 +1,33 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cli
+
+import (
+	"time"
+
+	"github.com/pulumi/esc/internal/env"
+)
+
+// Lifetime is the default lifetime used by `esc env open` and `esc env run` when
+// --lifetime is not specified.
+var Lifetime = env.Duration(
+	"PULUMI_ESC_LIFETIME",
+	"The default lifetime for opened environments, in the form accepted by time.ParseDuration.",
+	2*time.Hour)
+
+// Format is the default output format used by `esc env open` when --format is not
+// specified.
+var Format = env.String(
+	"PULUMI_ESC_FORMAT",
+	"The default output format for `esc env open`.",
+	"json")
+
+// API is the base URL of the ESC API to use instead of the default SaaS endpoint.
+//
+// Not yet consulted by the client constructor (cmd/esc/cli/client, not present in this
+// tree) — descoped until that package is rewired, but kept here so it's still
+// self-documenting via `esc env vars`.
+var API = env.String(
+	"PULUMI_ESC_API",
+	"The base URL of the ESC API to talk to.",
+	"")
@@ -0,0 +1,184 @@
+// Copyright 2023, Pulumi Corporation.
+
+// Package cache implements a filesystem cache of opened environments, keyed by
+// org/environment name, so that repeated `esc env open` invocations within an
+// environment's lifetime do not need to round-trip to the ESC API.
+package cache
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gofrs/flock"
+	"github.com/pulumi/esc"
+	"github.com/pulumi/esc/internal/env"
+)
+
+// dirOverride points the cache at a non-default location via PULUMI_ESC_CACHE_DIR. Like
+// every declared variable, it is listed by `esc env vars`.
+var dirOverride = env.String(
+	"PULUMI_ESC_CACHE_DIR",
+	"The directory used to cache opened environments. Defaults to ~/.pulumi/esc/cache.",
+	"")
+
+// Entry is a single cached environment open.
+type Entry struct {
+	EnvID   string           `json:"envID"`
+	Env     *esc.Environment `json:"env"`
+	Expires time.Time        `json:"expires"`
+}
+
+// Dir returns the root directory under which cache entries are stored, creating it if
+// necessary. It defaults to ~/.pulumi/esc/cache, and can be overridden with
+// PULUMI_ESC_CACHE_DIR.
+func Dir() (string, error) {
+	dir := dirOverride.Value()
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = filepath.Join(home, ".pulumi", "esc", "cache")
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+	return dir, nil
+}
+
+func entryPath(dir, org, env string) string {
+	return filepath.Join(dir, org, env+".json")
+}
+
+func lockPath(dir, org, env string) string {
+	return entryPath(dir, org, env) + ".lock"
+}
+
+// readEntry reads the cache entry for org/env without locking. The second return value
+// is false if there is no cache entry, regardless of error.
+func readEntry(dir, org, env string) (*Entry, bool, error) {
+	bytes, err := os.ReadFile(entryPath(dir, org, env))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	var entry Entry
+	if err := json.Unmarshal(bytes, &entry); err != nil {
+		return nil, false, err
+	}
+	return &entry, true, nil
+}
+
+// writeEntry writes entry to the cache for org/env without locking, creating parent
+// directories as needed.
+func writeEntry(dir, org, env string, entry *Entry) error {
+	path := entryPath(dir, org, env)
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	bytes, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, bytes, 0o600)
+}
+
+// Get returns the cached entry for org/env, if any. The second return value is false if
+// there is no cache entry, regardless of error.
+func Get(org, env string) (*Entry, bool, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, org), 0o700); err != nil {
+		return nil, false, err
+	}
+	lock := flock.New(lockPath(dir, org, env))
+	if err := lock.Lock(); err != nil {
+		return nil, false, err
+	}
+	defer lock.Unlock()
+
+	return readEntry(dir, org, env)
+}
+
+// Put writes entry to the cache for org/env, creating parent directories as needed.
+func Put(org, env string, entry *Entry) error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, org), 0o700); err != nil {
+		return err
+	}
+	lock := flock.New(lockPath(dir, org, env))
+	if err := lock.Lock(); err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return writeEntry(dir, org, env, entry)
+}
+
+// Open returns the cached entry for org/env if it is still valid (as determined by
+// valid), or otherwise calls refresh to obtain a fresh entry, caches it, and returns it.
+// The entire check-then-refresh-then-write sequence runs under a single file lock per
+// org/env, so that concurrent callers racing on a cold or expired cache collapse onto a
+// single call to refresh: only the first caller to acquire the lock actually refreshes,
+// and every other caller re-checks the now-populated cache before deciding to do so
+// itself.
+func Open(org, env string, valid func(*Entry) bool, refresh func() (*Entry, error)) (*Entry, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := os.MkdirAll(filepath.Join(dir, org), 0o700); err != nil {
+		return nil, err
+	}
+	lock := flock.New(lockPath(dir, org, env))
+	if err := lock.Lock(); err != nil {
+		return nil, err
+	}
+	defer lock.Unlock()
+
+	if entry, ok, err := readEntry(dir, org, env); err == nil && ok && valid(entry) {
+		return entry, nil
+	}
+
+	entry, err := refresh()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := writeEntry(dir, org, env, entry); err != nil {
+		return nil, err
+	}
+	return entry, nil
+}
+
+// Clear removes all cached entries.
+func Clear() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, e.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
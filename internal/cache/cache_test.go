@@ -0,0 +1,63 @@
+// Copyright 2023, Pulumi Corporation.
+
+package cache
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOpenCollapsesConcurrentRefresh(t *testing.T) {
+	t.Setenv("PULUMI_ESC_CACHE_DIR", t.TempDir())
+
+	var refreshCount int32
+	refresh := func() (*Entry, error) {
+		atomic.AddInt32(&refreshCount, 1)
+		time.Sleep(50 * time.Millisecond)
+		return &Entry{EnvID: "env-id", Expires: time.Now().Add(time.Hour)}, nil
+	}
+	valid := func(e *Entry) bool {
+		return time.Now().Before(e.Expires)
+	}
+
+	start := make(chan struct{})
+	errs := make([]error, 2)
+	var wg sync.WaitGroup
+	for i := range errs {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			_, errs[i] = Open("org", "env", valid, refresh)
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, refreshCount,
+		"only the first caller should refresh; the second should observe the now-cached entry")
+}
+
+func TestClear(t *testing.T) {
+	t.Setenv("PULUMI_ESC_CACHE_DIR", t.TempDir())
+
+	require.NoError(t, Put("org", "env", &Entry{EnvID: "env-id"}))
+	_, ok, err := Get("org", "env")
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	require.NoError(t, Clear())
+
+	_, ok, err = Get("org", "env")
+	require.NoError(t, err)
+	assert.False(t, ok, "Clear should remove all cached entries")
+}
@@ -0,0 +1,167 @@
+// Copyright 2023, Pulumi Corporation.
+
+// Package env declares the PULUMI_ESC_* environment variables consumed by the ESC CLI.
+// Each variable is declared once, as a module-level value, and registers itself so that
+// `esc env vars` can enumerate every variable the CLI understands along with its current
+// value.
+package env
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// Var is a declared environment variable.
+type Var struct {
+	name        string
+	description string
+	needs       *Var
+	stringValue func() string
+}
+
+var registry []*Var
+
+// All returns every declared variable, in declaration order.
+func All() []*Var {
+	return registry
+}
+
+// Name is the variable's name, e.g. "PULUMI_ESC_LIFETIME".
+func (v *Var) Name() string {
+	return v.name
+}
+
+// Description explains what the variable controls.
+func (v *Var) Description() string {
+	return v.description
+}
+
+// String renders the variable's current value as a string, for display purposes (e.g.
+// `esc env vars`). Typed accessors (StringValue.Value, BoolValue.Value, etc.) should be
+// preferred wherever the concrete type is known.
+func (v *Var) String() string {
+	if v.stringValue == nil {
+		return ""
+	}
+	return v.stringValue()
+}
+
+// active reports whether v's value should be honored: true if it has no predicate
+// variable, or its predicate variable is truthy.
+func (v *Var) active() bool {
+	if v.needs == nil {
+		return true
+	}
+	b, _ := strconv.ParseBool(os.Getenv(v.needs.name))
+	return b
+}
+
+// Option configures a declared variable.
+type Option func(*Var)
+
+// Needs gates a variable so that it only takes effect when other is set to a truthy
+// value.
+func Needs(other *Var) Option {
+	return func(v *Var) { v.needs = other }
+}
+
+func declare(name, description string, opts ...Option) *Var {
+	v := &Var{name: name, description: description}
+	for _, o := range opts {
+		o(v)
+	}
+	registry = append(registry, v)
+	return v
+}
+
+// StringValue is a string-valued environment variable.
+type StringValue struct {
+	*Var
+	defaultValue string
+}
+
+// String declares a string-valued environment variable.
+func String(name, description, defaultValue string, opts ...Option) StringValue {
+	v := StringValue{Var: declare(name, description, opts...), defaultValue: defaultValue}
+	v.Var.stringValue = v.Value
+	return v
+}
+
+// Default is the value used when the variable is unset or inactive.
+func (v StringValue) Default() string {
+	return v.defaultValue
+}
+
+// Value returns the variable's current value: the default if the variable is unset, or
+// gated inactive by a Needs predicate.
+func (v StringValue) Value() string {
+	if !v.active() {
+		return v.defaultValue
+	}
+	if s, ok := os.LookupEnv(v.name); ok {
+		return s
+	}
+	return v.defaultValue
+}
+
+// BoolValue is a bool-valued environment variable.
+type BoolValue struct {
+	*Var
+}
+
+// Bool declares a bool-valued environment variable, defaulting to false.
+func Bool(name, description string, opts ...Option) BoolValue {
+	v := BoolValue{Var: declare(name, description, opts...)}
+	v.Var.stringValue = func() string { return strconv.FormatBool(v.Value()) }
+	return v
+}
+
+// Default is the value used when the variable is unset or inactive.
+func (v BoolValue) Default() bool {
+	return false
+}
+
+// Value returns the variable's current value, parsed with strconv.ParseBool.
+func (v BoolValue) Value() bool {
+	if !v.active() {
+		return false
+	}
+	b, _ := strconv.ParseBool(os.Getenv(v.name))
+	return b
+}
+
+// DurationValue is a duration-valued environment variable.
+type DurationValue struct {
+	*Var
+	defaultValue time.Duration
+}
+
+// Duration declares a duration-valued environment variable, parsed with
+// time.ParseDuration.
+func Duration(name, description string, defaultValue time.Duration, opts ...Option) DurationValue {
+	v := DurationValue{Var: declare(name, description, opts...), defaultValue: defaultValue}
+	v.Var.stringValue = func() string { return v.Value().String() }
+	return v
+}
+
+// Default is the value used when the variable is unset, inactive, or unparseable.
+func (v DurationValue) Default() time.Duration {
+	return v.defaultValue
+}
+
+// Value returns the variable's current value.
+func (v DurationValue) Value() time.Duration {
+	if !v.active() {
+		return v.defaultValue
+	}
+	s, ok := os.LookupEnv(v.name)
+	if !ok {
+		return v.defaultValue
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return v.defaultValue
+	}
+	return d
+}
@@ -0,0 +1,69 @@
+// Copyright 2023, Pulumi Corporation.
+
+package env
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStringValue(t *testing.T) {
+	v := String("ESC_ENV_TEST_STRING", "a test string var", "default")
+
+	assert.Equal(t, "default", v.Value())
+
+	t.Setenv("ESC_ENV_TEST_STRING", "set-value")
+	assert.Equal(t, "set-value", v.Value())
+}
+
+func TestBoolValue(t *testing.T) {
+	v := Bool("ESC_ENV_TEST_BOOL", "a test bool var")
+
+	assert.False(t, v.Value())
+
+	t.Setenv("ESC_ENV_TEST_BOOL", "true")
+	assert.True(t, v.Value())
+
+	t.Setenv("ESC_ENV_TEST_BOOL", "false")
+	assert.False(t, v.Value())
+}
+
+func TestDurationValue(t *testing.T) {
+	v := Duration("ESC_ENV_TEST_DURATION", "a test duration var", time.Hour)
+
+	assert.Equal(t, time.Hour, v.Value())
+
+	t.Setenv("ESC_ENV_TEST_DURATION", "30m")
+	assert.Equal(t, 30*time.Minute, v.Value())
+
+	t.Setenv("ESC_ENV_TEST_DURATION", "not-a-duration")
+	assert.Equal(t, time.Hour, v.Value(), "unparseable values fall back to the default")
+}
+
+func TestNeedsGating(t *testing.T) {
+	gate := Bool("ESC_ENV_TEST_GATE", "gates another var")
+	gated := String("ESC_ENV_TEST_GATED", "gated by ESC_ENV_TEST_GATE", "default", Needs(gate.Var))
+
+	t.Setenv("ESC_ENV_TEST_GATED", "set-value")
+
+	assert.Equal(t, "default", gated.Value(), "inactive predicate should mask the set value")
+
+	t.Setenv("ESC_ENV_TEST_GATE", "true")
+	assert.Equal(t, "set-value", gated.Value(), "active predicate should reveal the set value")
+}
+
+func TestAllIncludesDeclaredVars(t *testing.T) {
+	name := "ESC_ENV_TEST_REGISTERED"
+	String(name, "registered for All() coverage", "")
+
+	var found bool
+	for _, v := range All() {
+		if v.Name() == name {
+			found = true
+			break
+		}
+	}
+	assert.True(t, found, "declared variables must appear in All()")
+}